@@ -6,120 +6,114 @@ which subscribers Register to pick up those messages.
 */
 package broadcast
 
-import "sync"
+import "context"
 
+// broadcaster is a thin wrapper around typedBroadcaster[interface{}]
+// that adds the context-aware helpers Broadcaster exposes on top of
+// TypedBroadcaster[interface{}].
 type broadcaster struct {
-	input chan interface{}
-	reg   chan chan<- interface{}
-	unreg chan chan<- interface{}
-
-	outputs map[chan<- interface{}]bool
-
-	stopCh    chan struct{}
-	wg        sync.WaitGroup
-	sync.Once // close once
+	*typedBroadcaster[interface{}]
 }
 
 // The Broadcaster interface describes the main entry points to
-// broadcasters.
+// broadcasters. It is TypedBroadcaster[interface{}] plus context-aware
+// helpers for bounding how long a caller waits.
 type Broadcaster interface {
-	// Register a new channel to receive broadcasts
-	Register(chan<- interface{})
-	// Unregister a channel so that it no longer receives broadcasts.
-	Unregister(chan<- interface{})
-	// Shut this broadcaster down.
-	Close() error
-	// Submit a new object to all subscribers
-	Submit(interface{})
-	// Try Submit a new object to all subscribers return false if input chan is fill
-	TrySubmit(interface{}) bool
-}
-
-func (b *broadcaster) broadcast(m interface{}) {
-	for ch := range b.outputs {
-		select {
-		case ch <- m:
-		case <-b.stopCh:
-		}
-	}
-}
-
-func (b *broadcaster) run() {
-	defer b.wg.Done()
-	for {
-		select {
-		case <-b.stopCh:
-			return
-		case m := <-b.input:
-			b.broadcast(m)
-		case ch, ok := <-b.reg:
-			if ok {
-				b.outputs[ch] = true
-			} else {
-				return
-			}
-		case ch := <-b.unreg:
-			delete(b.outputs, ch)
-		}
-	}
+	TypedBroadcaster[interface{}]
+	// Shutdown drains any messages already queued in the input channel
+	// out to subscribers before closing them, returning early with
+	// ctx.Err() if ctx expires before the drain finishes.
+	Shutdown(ctx context.Context) error
+	// SubmitContext submits a new object to all subscribers, returning
+	// ctx.Err() if ctx expires before the input channel accepts it.
+	SubmitContext(ctx context.Context, m interface{}) error
+	// RegisterContext registers a new channel to receive broadcasts,
+	// returning ctx.Err() if ctx expires before the registration is queued.
+	RegisterContext(ctx context.Context, ch chan<- interface{}) error
 }
 
 // NewBroadcaster creates a new broadcaster with the given input
 // channel buffer length.
 func NewBroadcaster(buflen int) Broadcaster {
-	b := &broadcaster{
-		input:   make(chan interface{}, buflen),
-		reg:     make(chan chan<- interface{}),
-		unreg:   make(chan chan<- interface{}),
-		outputs: make(map[chan<- interface{}]bool),
-		stopCh:  make(chan struct{}),
-	}
-
-	b.wg.Add(1)
-	go b.run()
-
-	return b
-}
-
-func (b *broadcaster) Register(newch chan<- interface{}) {
-	b.reg <- newch
+	return &broadcaster{typedBroadcaster: newTypedBroadcaster[interface{}](buflen)}
 }
 
-func (b *broadcaster) Unregister(newch chan<- interface{}) {
-	b.unreg <- newch
+// Submit an item to be broadcast to all listeners. Defined directly on
+// broadcaster, rather than left to promotion from typedBroadcaster, so
+// the nil check runs against the outer *broadcaster before the embedded
+// pointer is ever dereferenced.
+func (b *broadcaster) Submit(m interface{}) {
+	if b == nil {
+		return
+	}
+	b.typedBroadcaster.Submit(m)
 }
 
-func (b *broadcaster) Close() error {
-	b.Do(func() {
-		close(b.stopCh)
-		b.wg.Wait()
-		close(b.reg)               // not allowed to register anymore.
-		close(b.unreg)             // not allowed to unregister anymore.
-		close(b.input)             // not allowed to submit anymore.
-		for v := range b.outputs { // close all registered channel.
-			close(v)
-		}
-	})
-	return nil
+// TrySubmit attempts to submit an item to be broadcast, returning true
+// iff the item was queued in the input channel, else false. See Submit for why this
+// isn't just left to promotion.
+func (b *broadcaster) TrySubmit(m interface{}) bool {
+	if b == nil {
+		return false
+	}
+	return b.typedBroadcaster.TrySubmit(m)
 }
 
-// Submit an item to be broadcast to all listeners.
-func (b *broadcaster) Submit(m interface{}) {
-	if b != nil {
-		b.input <- m
+// RegisterContext registers newch, returning ctx.Err() if ctx expires
+// before the registration is queued with the run loop.
+func (b *broadcaster) RegisterContext(ctx context.Context, newch chan<- interface{}) error {
+	select {
+	case b.reg <- regRequest[interface{}]{ch: newch}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// TrySubmit attempts to submit an item to be broadcast, returning
-// true iff it the item was broadcast, else false.
-func (b *broadcaster) TrySubmit(m interface{}) bool {
+// SubmitContext submits an item to be broadcast to all listeners,
+// returning ctx.Err() if ctx expires before the input channel accepts it.
+func (b *broadcaster) SubmitContext(ctx context.Context, m interface{}) error {
 	if b == nil {
-		return false
+		return nil
 	}
 	select {
 	case b.input <- m:
-		return true
-	default:
-		return false
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
+
+// Shutdown drains any messages already queued in input out to
+// subscribers before closing them. If ctx expires first — including
+// while run() is still stuck delivering to a slow subscriber and hasn't
+// reached the point of receiving the drain request at all — it stops
+// waiting and returns ctx.Err(), while the broadcaster still shuts down
+// in the background.
+func (b *broadcaster) Shutdown(ctx context.Context) error {
+	var err error
+	b.Do(func() {
+		reply := make(chan struct{})
+		select {
+		case b.drain <- reply:
+			select {
+			case <-reply:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		close(b.stopCh)
+		if err != nil {
+			go func() {
+				b.wg.Wait()
+				b.cleanup()
+			}()
+			return
+		}
+		b.wg.Wait()
+		b.cleanup()
+	})
+	return err
+}
@@ -0,0 +1,90 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitRegisterAppliesBeforeSubmit(t *testing.T) {
+	b := NewBroadcaster(1)
+	defer b.Close()
+
+	ch := make(chan interface{}, 1)
+	<-b.WaitRegister(ch)
+
+	b.Submit("first")
+
+	select {
+	case got := <-ch:
+		if got != "first" {
+			t.Fatalf("got %v, want %q", got, "first")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message after WaitRegister")
+	}
+}
+
+func TestWaitUnregisterAppliesBeforeSubmit(t *testing.T) {
+	b := NewBroadcaster(1)
+	defer b.Close()
+
+	ch := make(chan interface{}, 1)
+	<-b.WaitRegister(ch)
+	<-b.WaitUnregister(ch)
+
+	b.Submit("ignored")
+
+	select {
+	case got := <-ch:
+		t.Fatalf("unregistered channel still received %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestShutdownDrainsQueuedMessages(t *testing.T) {
+	b := NewBroadcaster(3)
+	ch := make(chan interface{}, 3)
+	<-b.WaitRegister(ch)
+
+	for _, m := range []string{"a", "b", "c"} {
+		if err := b.SubmitContext(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	var got []interface{}
+	for m := range ch {
+		got = append(got, m)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3: %v", len(got), got)
+	}
+}
+
+func TestShutdownReturnsEarlyOnContextCancellation(t *testing.T) {
+	b := NewBroadcaster(1)
+	slow := make(chan interface{}) // never read, so run() stalls delivering to it
+	<-b.WaitRegister(slow)
+	b.Submit("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := b.Shutdown(ctx)
+	elapsed := time.Since(start)
+
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("Shutdown blocked for %v past its context deadline", elapsed)
+	}
+}
@@ -0,0 +1,247 @@
+package broadcast
+
+import "sync"
+
+// SlowSubscriberPolicy controls what a ManagedBroadcaster does when a
+// subscriber's channel is full and cannot immediately accept a message.
+type SlowSubscriberPolicy int
+
+const (
+	// PolicyBlock blocks broadcast() until the slow subscriber can
+	// accept the message, or the broadcaster is closed. This is the
+	// behavior of NewBroadcaster.
+	PolicyBlock SlowSubscriberPolicy = iota
+	// PolicyDrop skips the send to a subscriber whose channel is full.
+	PolicyDrop
+	// PolicyDropOldest drains one queued message from the subscriber's
+	// channel to make room, then sends, giving ring-buffer semantics.
+	PolicyDropOldest
+	// PolicyDisconnect unregisters and closes a subscriber after
+	// Options.MaxFailures consecutive failed sends.
+	PolicyDisconnect
+)
+
+// Options configures a broadcaster created with NewBroadcasterWithOptions.
+type Options struct {
+	// Policy controls what happens when a subscriber's channel is full.
+	Policy SlowSubscriberPolicy
+	// MaxFailures is the number of consecutive failed sends before a
+	// subscriber is disconnected under PolicyDisconnect. Ignored by
+	// other policies. Defaults to 1 if zero.
+	MaxFailures int
+}
+
+// Stats is a point-in-time snapshot of delivery outcomes for a
+// ManagedBroadcaster's configured SlowSubscriberPolicy.
+type Stats struct {
+	// Dropped counts messages skipped under PolicyDrop or PolicyDropOldest.
+	Dropped uint64
+	// Disconnected counts subscribers removed under PolicyDisconnect.
+	Disconnected uint64
+}
+
+// ManagedBroadcaster is a broadcaster that applies a SlowSubscriberPolicy
+// to subscribers whose channels are full, instead of blocking every
+// other subscriber on the slowest one. Because PolicyDropOldest needs to
+// drain a queued message from a subscriber's channel, subscribers are
+// registered with a bidirectional channel rather than the send-only
+// channel used by Broadcaster.
+type ManagedBroadcaster interface {
+	// Register a new channel to receive broadcasts.
+	Register(chan interface{})
+	// Unregister a channel so that it no longer receives broadcasts.
+	Unregister(chan interface{})
+	// Shut this broadcaster down.
+	Close() error
+	// Submit a new object to all subscribers.
+	Submit(interface{})
+	// TrySubmit a new object to all subscribers, returning false if the
+	// input chan is full.
+	TrySubmit(interface{}) bool
+	// Stats returns a snapshot of delivery outcomes so far.
+	Stats() Stats
+}
+
+type managedBroadcaster struct {
+	input chan interface{}
+	reg   chan chan interface{}
+	unreg chan chan interface{}
+
+	outputs  map[chan interface{}]bool
+	failures map[chan interface{}]int
+	opts     Options
+
+	statsMu sync.Mutex
+	stats   Stats
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	sync.Once
+}
+
+// addDropped and addDisconnected update stats under statsMu, since
+// Stats() reads it from outside the run goroutine.
+func (b *managedBroadcaster) addDropped() {
+	b.statsMu.Lock()
+	b.stats.Dropped++
+	b.statsMu.Unlock()
+}
+
+func (b *managedBroadcaster) addDisconnected() {
+	b.statsMu.Lock()
+	b.stats.Disconnected++
+	b.statsMu.Unlock()
+}
+
+// NewBroadcasterWithOptions creates a new broadcaster with the given
+// input channel buffer length, applying opts.Policy to subscribers
+// whose channel is full instead of blocking the whole broadcast.
+func NewBroadcasterWithOptions(buflen int, opts Options) ManagedBroadcaster {
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = 1
+	}
+
+	b := &managedBroadcaster{
+		input:    make(chan interface{}, buflen),
+		reg:      make(chan chan interface{}),
+		unreg:    make(chan chan interface{}),
+		outputs:  make(map[chan interface{}]bool),
+		failures: make(map[chan interface{}]int),
+		opts:     opts,
+		stopCh:   make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+func (b *managedBroadcaster) send(ch chan interface{}, m interface{}) {
+	switch b.opts.Policy {
+	case PolicyDrop:
+		select {
+		case ch <- m:
+			delete(b.failures, ch)
+		default:
+			b.addDropped()
+		}
+	case PolicyDropOldest:
+		select {
+		case ch <- m:
+			delete(b.failures, ch)
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- m:
+			default:
+			}
+			// One drop is counted here either way. Normally the lost
+			// message is the oldest one we just drained, or (if nothing
+			// could be drained, e.g. unbuffered) m itself; if the
+			// subscriber reads concurrently, nothing may actually be lost.
+			b.addDropped()
+		}
+	case PolicyDisconnect:
+		select {
+		case ch <- m:
+			delete(b.failures, ch)
+		default:
+			b.failures[ch]++
+			if b.failures[ch] >= b.opts.MaxFailures {
+				b.addDisconnected()
+				delete(b.outputs, ch)
+				delete(b.failures, ch)
+				close(ch)
+			}
+		}
+	default: // PolicyBlock
+		select {
+		case ch <- m:
+		case <-b.stopCh:
+		}
+	}
+}
+
+func (b *managedBroadcaster) broadcast(m interface{}) {
+	for ch := range b.outputs {
+		b.send(ch, m)
+	}
+}
+
+func (b *managedBroadcaster) run() {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case m := <-b.input:
+			b.broadcast(m)
+		case ch, ok := <-b.reg:
+			if ok {
+				b.outputs[ch] = true
+			} else {
+				return
+			}
+		case ch := <-b.unreg:
+			delete(b.outputs, ch)
+			delete(b.failures, ch)
+		}
+	}
+}
+
+func (b *managedBroadcaster) Register(newch chan interface{}) {
+	b.reg <- newch
+}
+
+func (b *managedBroadcaster) Unregister(newch chan interface{}) {
+	b.unreg <- newch
+}
+
+func (b *managedBroadcaster) Close() error {
+	b.Do(func() {
+		close(b.stopCh)
+		b.wg.Wait()
+		close(b.reg)
+		close(b.unreg)
+		close(b.input)
+		for v := range b.outputs {
+			close(v)
+		}
+	})
+	return nil
+}
+
+// Submit an item to be broadcast to all listeners.
+func (b *managedBroadcaster) Submit(m interface{}) {
+	if b != nil {
+		b.input <- m
+	}
+}
+
+// TrySubmit attempts to submit an item to be broadcast, returning true
+// iff the item was queued in the input channel, else false.
+func (b *managedBroadcaster) TrySubmit(m interface{}) bool {
+	if b == nil {
+		return false
+	}
+	select {
+	case b.input <- m:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stats returns a snapshot of delivery outcomes for this broadcaster's
+// configured SlowSubscriberPolicy. Safe to call at any time, including
+// after Close, since it reads statsMu-guarded state rather than
+// round-tripping through the (possibly stopped) run loop.
+func (b *managedBroadcaster) Stats() Stats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	return b.stats
+}
@@ -0,0 +1,104 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func waitForStats(t *testing.T, b ManagedBroadcaster, want func(Stats) bool) Stats {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		if s := b.Stats(); want(s) {
+			return s
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for stats, last seen: %+v", b.Stats())
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestPolicyDropCountsDropped(t *testing.T) {
+	b := NewBroadcasterWithOptions(1, Options{Policy: PolicyDrop})
+	defer b.Close()
+
+	ch := make(chan interface{}) // unbuffered: a send with no concurrent reader always fails
+	b.Register(ch)
+
+	b.Submit("a")
+
+	waitForStats(t, b, func(s Stats) bool { return s.Dropped == 1 })
+}
+
+func TestPolicyDropOldestEvictsOldestAndCounts(t *testing.T) {
+	b := NewBroadcasterWithOptions(2, Options{Policy: PolicyDropOldest})
+	defer b.Close()
+
+	ch := make(chan interface{}, 1)
+	b.Register(ch)
+
+	b.Submit("old")
+	b.Submit("new")
+
+	// Don't read from ch until the drop is accounted for: reading early
+	// would race with the broadcaster's own drain of the same channel.
+	waitForStats(t, b, func(s Stats) bool { return s.Dropped == 1 })
+
+	select {
+	case got := <-ch:
+		if got != "new" {
+			t.Fatalf("got %v, want %q (oldest should have been evicted)", got, "new")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+}
+
+func TestPolicyDisconnectClosesSubscriberAfterMaxFailures(t *testing.T) {
+	b := NewBroadcasterWithOptions(2, Options{Policy: PolicyDisconnect, MaxFailures: 2})
+	defer b.Close()
+
+	ch := make(chan interface{}) // unbuffered, never read -> every send fails
+	b.Register(ch)
+
+	b.Submit("a")
+	b.Submit("b")
+
+	// Wait for the disconnect to be recorded before reading from ch:
+	// reading earlier would race with the broadcaster's own (intentionally
+	// failing) sends and could itself accept a delivery.
+	waitForStats(t, b, func(s Stats) bool { return s.Disconnected == 1 })
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected subscriber channel to be closed, got a value instead")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscriber channel to be closed")
+	}
+}
+
+func TestPolicyBlockDeliversWithoutDropping(t *testing.T) {
+	b := NewBroadcasterWithOptions(1, Options{Policy: PolicyBlock})
+	defer b.Close()
+
+	ch := make(chan interface{}, 1)
+	b.Register(ch)
+
+	b.Submit("a")
+
+	select {
+	case got := <-ch:
+		if got != "a" {
+			t.Fatalf("got %v, want %q", got, "a")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	if s := b.Stats(); s.Dropped != 0 || s.Disconnected != 0 {
+		t.Fatalf("Stats = %+v, want zero value", s)
+	}
+}
@@ -0,0 +1,146 @@
+package broadcast
+
+import "sync"
+
+// WildcardTopic is the special topic name that receives every message
+// submitted to a TopicBroadcaster, regardless of the topic it was
+// submitted under.
+const WildcardTopic = "*"
+
+type topicMessage struct {
+	topic string
+	m     interface{}
+}
+
+type topicReg struct {
+	topic string
+	ch    chan<- interface{}
+}
+
+type topicBroadcaster struct {
+	input chan topicMessage
+	reg   chan topicReg
+	unreg chan topicReg
+
+	outputs map[string]map[chan<- interface{}]bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	sync.Once
+}
+
+// TopicBroadcaster describes a broadcaster that dispatches messages to
+// subscribers based on a topic string, so a single broadcaster can fan
+// out heterogeneous streams of messages without every subscriber having
+// to filter out the topics it doesn't care about. Subscribers
+// registered under WildcardTopic receive every message regardless of
+// its topic.
+type TopicBroadcaster interface {
+	// RegisterTopic a new channel to receive broadcasts for topic.
+	RegisterTopic(topic string, ch chan<- interface{})
+	// UnregisterTopic a channel so it no longer receives broadcasts for topic.
+	UnregisterTopic(topic string, ch chan<- interface{})
+	// Close shuts this broadcaster down.
+	Close() error
+	// SubmitTopic submits a new object to all subscribers of topic.
+	SubmitTopic(topic string, m interface{})
+}
+
+func (b *topicBroadcaster) broadcast(tm topicMessage) {
+	sent := make(map[chan<- interface{}]bool)
+	for ch := range b.outputs[tm.topic] {
+		select {
+		case ch <- tm.m:
+		case <-b.stopCh:
+		}
+		sent[ch] = true
+	}
+	if tm.topic != WildcardTopic {
+		for ch := range b.outputs[WildcardTopic] {
+			if sent[ch] { // already delivered via the specific topic above.
+				continue
+			}
+			select {
+			case ch <- tm.m:
+			case <-b.stopCh:
+			}
+		}
+	}
+}
+
+func (b *topicBroadcaster) run() {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case tm := <-b.input:
+			b.broadcast(tm)
+		case r, ok := <-b.reg:
+			if ok {
+				subs, found := b.outputs[r.topic]
+				if !found {
+					subs = make(map[chan<- interface{}]bool)
+					b.outputs[r.topic] = subs
+				}
+				subs[r.ch] = true
+			} else {
+				return
+			}
+		case r := <-b.unreg:
+			delete(b.outputs[r.topic], r.ch)
+		}
+	}
+}
+
+// NewTopicBroadcaster creates a new topic-based broadcaster with the
+// given input channel buffer length.
+func NewTopicBroadcaster(buflen int) TopicBroadcaster {
+	b := &topicBroadcaster{
+		input:   make(chan topicMessage, buflen),
+		reg:     make(chan topicReg),
+		unreg:   make(chan topicReg),
+		outputs: make(map[string]map[chan<- interface{}]bool),
+		stopCh:  make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+func (b *topicBroadcaster) RegisterTopic(topic string, newch chan<- interface{}) {
+	b.reg <- topicReg{topic: topic, ch: newch}
+}
+
+func (b *topicBroadcaster) UnregisterTopic(topic string, newch chan<- interface{}) {
+	b.unreg <- topicReg{topic: topic, ch: newch}
+}
+
+func (b *topicBroadcaster) Close() error {
+	b.Do(func() {
+		close(b.stopCh)
+		b.wg.Wait()
+		close(b.reg)
+		close(b.unreg)
+		close(b.input)
+		closed := make(map[chan<- interface{}]bool)
+		for _, subs := range b.outputs { // a channel may appear under several topics.
+			for v := range subs {
+				if !closed[v] {
+					closed[v] = true
+					close(v)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// SubmitTopic submits an item to be broadcast to all subscribers of topic.
+func (b *topicBroadcaster) SubmitTopic(topic string, m interface{}) {
+	if b != nil {
+		b.input <- topicMessage{topic: topic, m: m}
+	}
+}
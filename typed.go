@@ -0,0 +1,188 @@
+package broadcast
+
+import "sync"
+
+// TypedBroadcaster is the generic counterpart to Broadcaster: it
+// broadcasts values of a single type T, so subscribers get compile-time
+// type safety and callers avoid the boxing/allocation of submitting
+// through interface{}. Broadcaster is a thin wrapper around
+// TypedBroadcaster[interface{}] that layers its context-aware helpers
+// (SubmitContext, RegisterContext, Shutdown) on top.
+type TypedBroadcaster[T any] interface {
+	// Register a new channel to receive broadcasts.
+	Register(chan<- T)
+	// Unregister a channel so that it no longer receives broadcasts.
+	Unregister(chan<- T)
+	// WaitRegister registers a new channel to receive broadcasts and
+	// returns a channel that is closed once the registration has been
+	// applied, so once it is closed the caller can be sure the channel
+	// will receive every value submitted afterwards.
+	WaitRegister(chan<- T) <-chan struct{}
+	// WaitUnregister unregisters a channel so that it no longer
+	// receives broadcasts and returns a channel that is closed once the
+	// unregistration has been applied. Waiting on it before closing the
+	// channel avoids a send-on-closed-channel panic from a broadcast
+	// that was already in flight.
+	WaitUnregister(chan<- T) <-chan struct{}
+	// Shut this broadcaster down.
+	Close() error
+	// Submit a new value to all subscribers.
+	Submit(T)
+	// TrySubmit a new value to all subscribers, returning false if the
+	// input chan is full.
+	TrySubmit(T) bool
+}
+
+type regRequest[T any] struct {
+	ch   chan<- T
+	done chan struct{}
+}
+
+type typedBroadcaster[T any] struct {
+	input chan T
+	reg   chan regRequest[T]
+	unreg chan regRequest[T]
+	drain chan chan struct{}
+
+	outputs map[chan<- T]bool
+
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	sync.Once // close once
+}
+
+func (b *typedBroadcaster[T]) broadcast(m T) {
+	for ch := range b.outputs {
+		select {
+		case ch <- m:
+		case <-b.stopCh:
+		}
+	}
+}
+
+func (b *typedBroadcaster[T]) run() {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case m := <-b.input:
+			b.broadcast(m)
+		case r, ok := <-b.reg:
+			if ok {
+				b.outputs[r.ch] = true
+				if r.done != nil {
+					close(r.done)
+				}
+			} else {
+				return
+			}
+		case r := <-b.unreg:
+			delete(b.outputs, r.ch)
+			if r.done != nil {
+				close(r.done)
+			}
+		case reply := <-b.drain:
+			// Flush whatever is already queued in input, then stop,
+			// instead of bailing mid-fanout like the stopCh case above.
+			for {
+				select {
+				case m := <-b.input:
+					b.broadcast(m)
+				default:
+					close(reply)
+					return
+				}
+			}
+		}
+	}
+}
+
+// newTypedBroadcaster builds the shared implementation behind both
+// NewTyped and NewBroadcaster.
+func newTypedBroadcaster[T any](buflen int) *typedBroadcaster[T] {
+	b := &typedBroadcaster[T]{
+		input:   make(chan T, buflen),
+		reg:     make(chan regRequest[T]),
+		unreg:   make(chan regRequest[T]),
+		drain:   make(chan chan struct{}),
+		outputs: make(map[chan<- T]bool),
+		stopCh:  make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+// NewTyped creates a new typed broadcaster with the given input channel
+// buffer length.
+func NewTyped[T any](buflen int) TypedBroadcaster[T] {
+	return newTypedBroadcaster[T](buflen)
+}
+
+func (b *typedBroadcaster[T]) Register(newch chan<- T) {
+	b.reg <- regRequest[T]{ch: newch}
+}
+
+func (b *typedBroadcaster[T]) Unregister(newch chan<- T) {
+	b.unreg <- regRequest[T]{ch: newch}
+}
+
+// WaitRegister registers newch, blocking until the run loop accepts the
+// request, and returns a channel that is closed once the registration
+// has been applied.
+func (b *typedBroadcaster[T]) WaitRegister(newch chan<- T) <-chan struct{} {
+	done := make(chan struct{})
+	b.reg <- regRequest[T]{ch: newch, done: done}
+	return done
+}
+
+// WaitUnregister unregisters newch, blocking until the run loop accepts
+// the request, and returns a channel that is closed once the removal has
+// been applied.
+func (b *typedBroadcaster[T]) WaitUnregister(newch chan<- T) <-chan struct{} {
+	done := make(chan struct{})
+	b.unreg <- regRequest[T]{ch: newch, done: done}
+	return done
+}
+
+func (b *typedBroadcaster[T]) cleanup() {
+	close(b.reg)               // not allowed to register anymore.
+	close(b.unreg)             // not allowed to unregister anymore.
+	close(b.input)             // not allowed to submit anymore.
+	for v := range b.outputs { // close all registered channel.
+		close(v)
+	}
+}
+
+func (b *typedBroadcaster[T]) Close() error {
+	b.Do(func() {
+		close(b.stopCh)
+		b.wg.Wait()
+		b.cleanup()
+	})
+	return nil
+}
+
+// Submit a value to be broadcast to all listeners.
+func (b *typedBroadcaster[T]) Submit(m T) {
+	if b != nil {
+		b.input <- m
+	}
+}
+
+// TrySubmit attempts to submit a value to be broadcast, returning true
+// iff the value was queued in the input channel, else false.
+func (b *typedBroadcaster[T]) TrySubmit(m T) bool {
+	if b == nil {
+		return false
+	}
+	select {
+	case b.input <- m:
+		return true
+	default:
+		return false
+	}
+}